@@ -5,24 +5,58 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
+	"path"
 	"syscall"
+	"time"
 
 	"github.com/sighupio/furyctl/internal/bootstrap"
+	"github.com/sighupio/furyctl/internal/diff"
 	"github.com/sighupio/furyctl/internal/project"
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/terraform/backend"
 	"github.com/sighupio/furyctl/pkg/analytics"
 	"github.com/sighupio/furyctl/pkg/terraform"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
-func bPre(cmd *cobra.Command, args []string) (err error) {
+// exitCodeDrift is returned by `bootstrap plan` when the generated plan
+// shows pending infrastructure changes, so CI can gate on it.
+const exitCodeDrift = 2
+
+// newBootstrapContext derives a cancellable context for a bootstrap
+// subcommand run: it is cancelled on SIGINT/SIGTERM and, when bTimeout is
+// set, after that deadline elapses. The returned cancel func must be
+// deferred by the caller.
+func newBootstrapContext() (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+
+	cancel := func() {}
+	if bTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, bTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-	handleStopSignal("bootstrap", stop)
 
+	go func() {
+		if _, ok := <-stop; ok {
+			log.Warn("interrupt received, cancelling the running terraform command gracefully...")
+			cancel()
+		}
+	}()
+
+	return ctx, cancel
+}
+
+func bPre(cmd *cobra.Command, args []string) (err error) {
 	log.Debug("passing pre-flight checks")
 	err = parseConfig(bConfigFilePath, "Bootstrap")
 	if err != nil {
@@ -38,14 +72,31 @@ func bPre(cmd *cobra.Command, args []string) (err error) {
 	prj = &project.Project{
 		Path: workingDirFullPath,
 	}
+
+	backendType := cfg.Executor.StateConfiguration.Backend
+	if backendType != "" && backendType != "local" {
+		log.Warnf("terraform state backend is %q, but the last-applied configuration used by "+
+			"bootstrap diff and the immutable-field check is still tracked locally in %v/.furyctl-state.yml; "+
+			"share that file across machines/CI runs (e.g. commit it) or pass --force on bootstrap update "+
+			"if it is missing", backendType, workingDirFullPath)
+	}
+
 	bootstrapOpts := &bootstrap.Options{
 		Spin:                     s,
 		Project:                  prj,
 		ProvisionerConfiguration: cfg,
+		ConfigPath:               bConfigFilePath,
+		StateStore:               state.NewLocalStorer(path.Join(workingDirFullPath, ".furyctl-state.yml")),
+		Force:                    bForce,
 		TerraformOpts: &terraform.Options{
 			GitHubToken: bGitHubToken,
 			WorkingDir:  workingDirFullPath,
 			Debug:       debug,
+			PlanOut:     bPlanOut,
+			Backend: backend.Config{
+				Type:   cfg.Executor.StateConfiguration.Backend,
+				Values: cfg.Executor.StateConfiguration.Config,
+			},
 		},
 	}
 	boot, err = bootstrap.New(bootstrapOpts)
@@ -63,6 +114,12 @@ var (
 	bWorkingDir     string
 	bGitHubToken    string
 	bDryRun         bool
+	bPlanOut        string
+	bForce          bool
+	bChangedOnly    bool
+	bDiffOutput     string
+	bTarget         string
+	bTimeout        time.Duration
 
 	bootstrapCmd = &cobra.Command{
 		Use:   "bootstrap",
@@ -87,7 +144,10 @@ var (
 				return fmt.Errorf("the project %v seems to be already created. Choose another working directory", bWorkingDir)
 			}
 
-			err = boot.Init()
+			ctx, cancel := newBootstrapContext()
+			defer cancel()
+
+			err = boot.Init(ctx)
 			if err != nil {
 				analytics.TrackBootstrapInit(bGitHubToken, false, cfg.Provisioner)
 				return err
@@ -106,7 +166,10 @@ var (
 				return fmt.Errorf("the project %v has to be created. Execute bootstrap init before bootstrap update. %v", bWorkingDir, err)
 			}
 
-			err = boot.Update(bDryRun)
+			ctx, cancel := newBootstrapContext()
+			defer cancel()
+
+			err = boot.Update(ctx, bDryRun, bTarget)
 			if err != nil {
 				analytics.TrackBootstrapUpdate(bGitHubToken, false, cfg.Provisioner, bDryRun)
 				return err
@@ -125,7 +188,10 @@ var (
 				return fmt.Errorf("the project %v has to be created. Execute bootstrap init before cluster destroy. %v", bWorkingDir, err)
 			}
 
-			err = boot.Destroy()
+			ctx, cancel := newBootstrapContext()
+			defer cancel()
+
+			err = boot.Destroy(ctx)
 			if err != nil {
 				analytics.TrackBootstrapDestroy(bGitHubToken, false, cfg.Provisioner)
 				return err
@@ -134,25 +200,115 @@ var (
 			return nil
 		},
 	}
+	// NOTE: the matching `cluster plan` command is not added here — this
+	// tree has no cmd/cluster.go to extend yet. Add it alongside bootstrap
+	// plan once the cluster command exists.
+	bootstrapPlanCmd = &cobra.Command{
+		Use:     "plan",
+		Short:   "Shows the infrastructure changes that bootstrap update would apply, without applying them",
+		PreRunE: bPre,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			err = prj.Check()
+			if err != nil {
+				return fmt.Errorf("the project %v has to be created. Execute bootstrap init before bootstrap plan. %v", bWorkingDir, err)
+			}
+
+			ctx, cancel := newBootstrapContext()
+			defer cancel()
+
+			driftDetected, err := boot.Plan(ctx)
+			if err != nil {
+				analytics.TrackBootstrapPlan(bGitHubToken, false, cfg.Provisioner, false)
+				return err
+			}
+			analytics.TrackBootstrapPlan(bGitHubToken, true, cfg.Provisioner, driftDetected)
+
+			if driftDetected {
+				log.Warnf("drift detected, see the generated plan in %v", bPlanOut)
+				os.Exit(exitCodeDrift)
+			}
+			return nil
+		},
+	}
+	bootstrapDiffCmd = &cobra.Command{
+		Use:     "diff",
+		Short:   "Shows the differences between the last applied configuration and the current bootstrap.yml",
+		PreRunE: bPre,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			err = prj.Check()
+			if err != nil {
+				return fmt.Errorf("the project %v has to be created. Execute bootstrap init before bootstrap diff. %v", bWorkingDir, err)
+			}
+
+			diffs, err := boot.Diff()
+			if err != nil {
+				return err
+			}
+
+			if bChangedOnly {
+				for _, p := range diff.TopLevelPaths(diffs) {
+					fmt.Println(p)
+				}
+				return nil
+			}
+
+			switch bDiffOutput {
+			case "json":
+				out, err := json.MarshalIndent(diffs, "", "  ")
+				if err != nil {
+					return fmt.Errorf("error marshalling diff as json: %w", err)
+				}
+				fmt.Println(string(out))
+			case "yaml":
+				for _, d := range diffs {
+					fmt.Printf("- path: %v\n  from: %v\n  to: %v\n", d.Path, d.From, d.To)
+				}
+			default:
+				return fmt.Errorf("unsupported output format %q, use yaml or json", bDiffOutput)
+			}
+
+			return nil
+		},
+	}
 )
 
 func init() {
 	bootstrapUpdateCmd.PersistentFlags().BoolVar(&bDryRun, "dry-run", false, "Dry run execution")
+	bootstrapUpdateCmd.PersistentFlags().BoolVar(&bForce, "force", false, "Bypass the immutable-field check and apply even if protected fields changed")
+	bootstrapUpdateCmd.PersistentFlags().StringVar(&bTarget, "target", "", "Only (re)generate up to this asset: config, immutable-check, terraform-init, terraform-apply, state-store")
 
 	bootstrapInitCmd.PersistentFlags().StringVarP(&bConfigFilePath, "config", "c", "bootstrap.yml", "Bootstrap configuration file path")
 	bootstrapUpdateCmd.PersistentFlags().StringVarP(&bConfigFilePath, "config", "c", "bootstrap.yml", "Bootstrap configuration file path")
 	bootstrapDestroyCmd.PersistentFlags().StringVarP(&bConfigFilePath, "config", "c", "bootstrap.yml", "Bootstrap configuration file path")
+	bootstrapPlanCmd.PersistentFlags().StringVarP(&bConfigFilePath, "config", "c", "bootstrap.yml", "Bootstrap configuration file path")
+	bootstrapDiffCmd.PersistentFlags().StringVarP(&bConfigFilePath, "config", "c", "bootstrap.yml", "Bootstrap configuration file path")
 
 	bootstrapInitCmd.PersistentFlags().StringVarP(&bWorkingDir, "workdir", "w", "./bootstrap", "Working directory to create and place all project files. Must not exists.")
 	bootstrapUpdateCmd.PersistentFlags().StringVarP(&bWorkingDir, "workdir", "w", "./bootstrap", "Working directory with all project files")
 	bootstrapDestroyCmd.PersistentFlags().StringVarP(&bWorkingDir, "workdir", "w", "./bootstrap", "Working directory with all project files")
+	bootstrapPlanCmd.PersistentFlags().StringVarP(&bWorkingDir, "workdir", "w", "./bootstrap", "Working directory with all project files")
+	bootstrapDiffCmd.PersistentFlags().StringVarP(&bWorkingDir, "workdir", "w", "./bootstrap", "Working directory with all project files")
 
 	bootstrapInitCmd.PersistentFlags().StringVarP(&bGitHubToken, "token", "t", "", "GitHub token to access enterprise repositories. Contact sales@sighup.io")
 	bootstrapUpdateCmd.PersistentFlags().StringVarP(&bGitHubToken, "token", "t", "", "GitHub token to access enterprise repositories. Contact sales@sighup.io")
 	bootstrapDestroyCmd.PersistentFlags().StringVarP(&bGitHubToken, "token", "t", "", "GitHub token to access enterprise repositories. Contact sales@sighup.io")
+	bootstrapPlanCmd.PersistentFlags().StringVarP(&bGitHubToken, "token", "t", "", "GitHub token to access enterprise repositories. Contact sales@sighup.io")
+	bootstrapDiffCmd.PersistentFlags().StringVarP(&bGitHubToken, "token", "t", "", "GitHub token to access enterprise repositories. Contact sales@sighup.io")
+
+	bootstrapPlanCmd.PersistentFlags().StringVarP(&bPlanOut, "out", "o", "./bootstrap/plan", "Directory where the plan artifacts (plan.tfplan, plan.json) are written")
+
+	bootstrapDiffCmd.PersistentFlags().BoolVar(&bChangedOnly, "changed-only", false, "Only list the top-level spec paths that changed")
+	bootstrapDiffCmd.PersistentFlags().StringVar(&bDiffOutput, "output", "yaml", "Diff output format: yaml or json")
+
+	bootstrapInitCmd.PersistentFlags().DurationVar(&bTimeout, "timeout", 0, "Abort the run, releasing any terraform state lock, after this duration. 0 disables the timeout")
+	bootstrapUpdateCmd.PersistentFlags().DurationVar(&bTimeout, "timeout", 0, "Abort the run, releasing any terraform state lock, after this duration. 0 disables the timeout")
+	bootstrapDestroyCmd.PersistentFlags().DurationVar(&bTimeout, "timeout", 0, "Abort the run, releasing any terraform state lock, after this duration. 0 disables the timeout")
+	bootstrapPlanCmd.PersistentFlags().DurationVar(&bTimeout, "timeout", 0, "Abort the run after this duration. 0 disables the timeout")
 
 	bootstrapCmd.AddCommand(bootstrapInitCmd)
 	bootstrapCmd.AddCommand(bootstrapUpdateCmd)
 	bootstrapCmd.AddCommand(bootstrapDestroyCmd)
+	bootstrapCmd.AddCommand(bootstrapPlanCmd)
+	bootstrapCmd.AddCommand(bootstrapDiffCmd)
 	rootCmd.AddCommand(bootstrapCmd)
 }
@@ -217,9 +217,9 @@ func (p *PreFlight) CheckStateDiffs() error {
 		return fmt.Errorf("error while creating rules builder: %w", err)
 	}
 
-	errs = append(errs, diffChecker.AssertImmutableViolations(diffs, r.GetImmutables("infrastructure"))...)
-	errs = append(errs, diffChecker.AssertImmutableViolations(diffs, r.GetImmutables("kubernetes"))...)
-	errs = append(errs, diffChecker.AssertImmutableViolations(diffs, r.GetImmutables("distribution"))...)
+	errs = append(errs, diff.AssertImmutableViolations(diffs, r.GetImmutables("infrastructure"))...)
+	errs = append(errs, diff.AssertImmutableViolations(diffs, r.GetImmutables("kubernetes"))...)
+	errs = append(errs, diff.AssertImmutableViolations(diffs, r.GetImmutables("distribution"))...)
 
 	if len(errs) > 0 {
 		return fmt.Errorf("%w: %s", errImmutable, errs)
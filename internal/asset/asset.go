@@ -0,0 +1,69 @@
+// Copyright (c) 2020 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package asset provides a small DAG runner for pipelines made of typed,
+// dependent generation stages (an "asset graph"), so that callers get
+// per-asset caching and the ability to re-run only up to a given stage.
+package asset
+
+import "fmt"
+
+// Asset is a single stage in an asset-generation pipeline. It depends on
+// zero or more other Assets and generates its own output from theirs.
+type Asset interface {
+	// Name uniquely identifies this asset within a Graph. It is used for
+	// caching and for --target style partial re-runs.
+	Name() string
+	// Dependencies returns the assets that must be generated before this
+	// one, in no particular order.
+	Dependencies() []Asset
+	// Generate produces this asset's result, given the already-generated
+	// results of its Dependencies(), keyed by their Name().
+	Generate(parents map[string]any) (any, error)
+}
+
+// Graph runs Assets in dependency order, generating each one at most once
+// for the lifetime of the Graph.
+type Graph struct {
+	results map[string]any
+	done    map[string]bool
+}
+
+// NewGraph creates an empty Graph.
+func NewGraph() *Graph {
+	return &Graph{
+		results: map[string]any{},
+		done:    map[string]bool{},
+	}
+}
+
+// Run generates target, recursively generating any of its Dependencies()
+// that were not already produced in this Graph, and returns target's
+// result.
+func (g *Graph) Run(target Asset) (any, error) {
+	if g.done[target.Name()] {
+		return g.results[target.Name()], nil
+	}
+
+	parents := make(map[string]any, len(target.Dependencies()))
+
+	for _, dep := range target.Dependencies() {
+		res, err := g.Run(dep)
+		if err != nil {
+			return nil, fmt.Errorf("error generating asset %q: %w", dep.Name(), err)
+		}
+
+		parents[dep.Name()] = res
+	}
+
+	res, err := target.Generate(parents)
+	if err != nil {
+		return nil, fmt.Errorf("error generating asset %q: %w", target.Name(), err)
+	}
+
+	g.results[target.Name()] = res
+	g.done[target.Name()] = true
+
+	return res, nil
+}
@@ -0,0 +1,388 @@
+// Copyright (c) 2020 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bootstrap drives the lifecycle of the infrastructure bootstrap
+// provisioner (init/update/destroy/plan/diff). Each stage is a typed
+// internal/asset.Asset, wired into a small dependency graph so that
+// cmd/bootstrap.go only has to ask for the terminal asset it needs.
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/briandowns/spinner"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sighupio/furyctl/internal/asset"
+	"github.com/sighupio/furyctl/internal/bootstrap/rules"
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/internal/diff"
+	"github.com/sighupio/furyctl/internal/project"
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/pkg/terraform"
+)
+
+// errImmutable is wrapped with the list of violated paths whenever
+// `bootstrap update` would change a field that must not be mutated once the
+// infrastructure has been applied.
+var errImmutable = errors.New("immutable path changed")
+
+// Asset names. All but AssetTerraformDestroy and AssetTerraformPlan are
+// usable as the `--target` of `bootstrap update`.
+const (
+	AssetConfig           = "config"
+	AssetImmutableCheck   = "immutable-check"
+	AssetTerraformInit    = "terraform-init"
+	AssetTerraformApply   = "terraform-apply"
+	AssetStateStore       = "state-store"
+	AssetTerraformDestroy = "terraform-destroy"
+	AssetTerraformPlan    = "terraform-plan"
+)
+
+// Options configures a Bootstrap provisioner run.
+type Options struct {
+	Spin                     *spinner.Spinner
+	Project                  *project.Project
+	ProvisionerConfiguration *configuration.Configuration
+	TerraformOpts            *terraform.Options
+	// ConfigPath is the path to the bootstrap.yml being applied.
+	ConfigPath string
+	// StateStore gives access to the configuration used in the last
+	// successful apply, to diff it against ConfigPath. Note that
+	// state.LocalStorer, the only implementation furyctl wires up today, is
+	// local to the working directory regardless of TerraformOpts.Backend.
+	StateStore state.Storer
+	// Force bypasses the immutable-field diff check on `bootstrap update`.
+	Force bool
+}
+
+// Bootstrap provisions the infrastructure required to later deploy a
+// Kubernetes cluster, driving terraform through pkg/terraform.
+type Bootstrap struct {
+	opts *Options
+	tf   *terraform.Runner
+}
+
+// New creates a Bootstrap provisioner from the given Options.
+func New(opts *Options) (*Bootstrap, error) {
+	if opts.TerraformOpts == nil {
+		return nil, fmt.Errorf("terraform options can not be nil")
+	}
+
+	return &Bootstrap{
+		opts: opts,
+		tf:   terraform.NewRunner(opts.TerraformOpts),
+	}, nil
+}
+
+// configAsset reads the on-disk bootstrap.yml.
+type configAsset struct {
+	b *Bootstrap
+}
+
+func (a *configAsset) Name() string                { return AssetConfig }
+func (a *configAsset) Dependencies() []asset.Asset { return nil }
+
+func (a *configAsset) Generate(_ map[string]any) (any, error) {
+	content, err := os.ReadFile(a.b.opts.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	return content, nil
+}
+
+// immutableCheckAsset fails when an immutable field changed since the last
+// successful apply, unless Options.Force is set.
+type immutableCheckAsset struct {
+	b      *Bootstrap
+	config asset.Asset
+}
+
+func (a *immutableCheckAsset) Name() string                { return AssetImmutableCheck }
+func (a *immutableCheckAsset) Dependencies() []asset.Asset { return []asset.Asset{a.config} }
+
+func (a *immutableCheckAsset) Generate(parents map[string]any) (any, error) {
+	if a.b.opts.Force {
+		return nil, nil
+	}
+
+	newCfg, ok := parents[AssetConfig].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("missing %q asset result", AssetConfig)
+	}
+
+	if err := a.b.checkImmutableDiffs(newCfg); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// terraformInitAsset renders the configured state backend and runs
+// `terraform init`.
+type terraformInitAsset struct {
+	b      *Bootstrap
+	config asset.Asset
+	ctx    context.Context //nolint:containedctx // assets are built per-call and scoped to a single run
+}
+
+func (a *terraformInitAsset) Name() string                { return AssetTerraformInit }
+func (a *terraformInitAsset) Dependencies() []asset.Asset { return []asset.Asset{a.config} }
+
+func (a *terraformInitAsset) Generate(_ map[string]any) (any, error) {
+	if err := a.b.tf.Init(a.ctx); err != nil {
+		return nil, fmt.Errorf("error initializing terraform: %w", err)
+	}
+
+	return nil, nil
+}
+
+// terraformApplyAsset runs `terraform apply` (or `terraform plan` when
+// dryRun is set).
+type terraformApplyAsset struct {
+	b      *Bootstrap
+	deps   []asset.Asset
+	dryRun bool
+	ctx    context.Context //nolint:containedctx // assets are built per-call and scoped to a single run
+}
+
+func (a *terraformApplyAsset) Name() string                { return AssetTerraformApply }
+func (a *terraformApplyAsset) Dependencies() []asset.Asset { return a.deps }
+
+func (a *terraformApplyAsset) Generate(_ map[string]any) (any, error) {
+	if err := a.b.tf.Apply(a.ctx, a.dryRun); err != nil {
+		return nil, fmt.Errorf("error applying terraform: %w", err)
+	}
+
+	return nil, nil
+}
+
+// stateStoreAsset persists the applied configuration, so future runs can
+// diff against it.
+type stateStoreAsset struct {
+	b      *Bootstrap
+	config asset.Asset
+	apply  asset.Asset
+}
+
+func (a *stateStoreAsset) Name() string { return AssetStateStore }
+
+func (a *stateStoreAsset) Dependencies() []asset.Asset {
+	return []asset.Asset{a.config, a.apply}
+}
+
+func (a *stateStoreAsset) Generate(parents map[string]any) (any, error) {
+	newCfg, ok := parents[AssetConfig].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("missing %q asset result", AssetConfig)
+	}
+
+	if err := a.b.opts.StateStore.StoreConfig(string(newCfg)); err != nil {
+		return nil, fmt.Errorf("error storing applied configuration: %w", err)
+	}
+
+	return nil, nil
+}
+
+// terraformDestroyAsset runs `terraform destroy`.
+type terraformDestroyAsset struct {
+	b    *Bootstrap
+	init asset.Asset
+	ctx  context.Context //nolint:containedctx // assets are built per-call and scoped to a single run
+}
+
+func (a *terraformDestroyAsset) Name() string                { return AssetTerraformDestroy }
+func (a *terraformDestroyAsset) Dependencies() []asset.Asset { return []asset.Asset{a.init} }
+
+func (a *terraformDestroyAsset) Generate(_ map[string]any) (any, error) {
+	if err := a.b.tf.Destroy(a.ctx); err != nil {
+		return nil, fmt.Errorf("error destroying terraform: %w", err)
+	}
+
+	return nil, nil
+}
+
+// terraformPlanAsset runs `terraform plan` and reports drift.
+type terraformPlanAsset struct {
+	b    *Bootstrap
+	init asset.Asset
+	ctx  context.Context //nolint:containedctx // assets are built per-call and scoped to a single run
+}
+
+func (a *terraformPlanAsset) Name() string                { return AssetTerraformPlan }
+func (a *terraformPlanAsset) Dependencies() []asset.Asset { return []asset.Asset{a.init} }
+
+func (a *terraformPlanAsset) Generate(_ map[string]any) (any, error) {
+	result, err := a.b.tf.Plan(a.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error planning terraform: %w", err)
+	}
+
+	return result.DriftDetected, nil
+}
+
+// Init creates the project directory and applies the infrastructure for the
+// first time. The run is aborted, releasing any held state lock, when ctx
+// is cancelled (e.g. on SIGINT/SIGTERM or when its deadline elapses).
+func (b *Bootstrap) Init(ctx context.Context) error {
+	if err := b.opts.Project.Create(); err != nil {
+		return fmt.Errorf("error creating project: %w", err)
+	}
+
+	config := &configAsset{b: b}
+	init := &terraformInitAsset{b: b, config: config, ctx: ctx}
+	apply := &terraformApplyAsset{b: b, deps: []asset.Asset{init}, ctx: ctx}
+	store := &stateStoreAsset{b: b, config: config, apply: apply}
+
+	_, err := asset.NewGraph().Run(store)
+
+	return err
+}
+
+// Update applies any pending infrastructure changes, optionally as a dry
+// run. Unless Options.Force is set, it first checks that none of the
+// provisioner's immutable fields changed since the last successful apply.
+// When target is non-empty, only the asset named target (and its
+// dependencies) is (re)generated, allowing callers to re-run a single stage
+// with `--target`. The run is aborted, releasing any held state lock and
+// leaving a diagnostic checkpoint noting which stage was interrupted, when
+// ctx is cancelled.
+func (b *Bootstrap) Update(ctx context.Context, dryRun bool, target string) error {
+	config := &configAsset{b: b}
+	immutableCheck := &immutableCheckAsset{b: b, config: config}
+	init := &terraformInitAsset{b: b, config: config, ctx: ctx}
+	apply := &terraformApplyAsset{b: b, deps: []asset.Asset{immutableCheck, init}, dryRun: dryRun, ctx: ctx}
+	store := &stateStoreAsset{b: b, config: config, apply: apply}
+
+	assets := map[string]asset.Asset{
+		AssetConfig:         config,
+		AssetImmutableCheck: immutableCheck,
+		AssetTerraformInit:  init,
+		AssetTerraformApply: apply,
+		AssetStateStore:     store,
+	}
+
+	terminal := asset.Asset(store)
+	if dryRun {
+		terminal = apply
+	}
+
+	if target != "" {
+		if dryRun && target == AssetStateStore {
+			return fmt.Errorf("--target=%s can not be used with --dry-run: terraform was never actually applied, "+
+				"so there is nothing to record as the last successfully applied configuration", AssetStateStore)
+		}
+
+		a, ok := assets[target]
+		if !ok {
+			return fmt.Errorf("unknown asset %q", target)
+		}
+
+		terminal = a
+	}
+
+	_, err := asset.NewGraph().Run(terminal)
+
+	return err
+}
+
+// checkImmutableDiffs compares newCfg against the configuration used in the
+// last successful apply, failing with errImmutable when a path declared
+// immutable for this provisioner has changed.
+func (b *Bootstrap) checkImmutableDiffs(newCfg []byte) error {
+	diffs, err := b.diffAppliedConfig(newCfg)
+	if errors.Is(err, state.ErrNoAppliedConfig) {
+		// Nothing applied yet, there is nothing to protect.
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	r, err := rules.NewBootstrapRulesBuilder(b.opts.ProvisionerConfiguration.Provisioner)
+	if err != nil {
+		return fmt.Errorf("error loading immutability rules: %w", err)
+	}
+
+	violations := diff.AssertImmutableViolations(diffs, r.GetImmutables())
+	if len(violations) > 0 {
+		return fmt.Errorf("%w: %s", errImmutable, violations)
+	}
+
+	return nil
+}
+
+// diffAppliedConfig diffs newCfg against the configuration used in the last
+// successful apply (Options.StateStore).
+func (b *Bootstrap) diffAppliedConfig(newCfg []byte) ([]diff.Diff, error) {
+	storedCfgStr, err := b.opts.StateStore.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error getting previously applied configuration: %w", err)
+	}
+
+	storedCfg := map[string]any{}
+	if err := yaml.Unmarshal([]byte(storedCfgStr), &storedCfg); err != nil {
+		return nil, fmt.Errorf("error parsing previously applied configuration: %w", err)
+	}
+
+	newCfgParsed := map[string]any{}
+	if err := yaml.Unmarshal(newCfg, &newCfgParsed); err != nil {
+		return nil, fmt.Errorf("error parsing config file: %w", err)
+	}
+
+	diffs, err := diff.NewBaseChecker(storedCfg, newCfgParsed).GenerateDiff()
+	if err != nil {
+		return nil, fmt.Errorf("error diffing configurations: %w", err)
+	}
+
+	return diffs, nil
+}
+
+// Diff compares the configuration used in the last successful apply against
+// the on-disk one, returning every path that changed. Callers get
+// state.ErrNoAppliedConfig back when bootstrap was never applied yet.
+func (b *Bootstrap) Diff() ([]diff.Diff, error) {
+	newCfg, err := os.ReadFile(b.opts.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	return b.diffAppliedConfig(newCfg)
+}
+
+// Destroy tears down the bootstrap infrastructure. The run is aborted,
+// releasing any held state lock, when ctx is cancelled.
+func (b *Bootstrap) Destroy(ctx context.Context) error {
+	config := &configAsset{b: b}
+	init := &terraformInitAsset{b: b, config: config, ctx: ctx}
+	destroy := &terraformDestroyAsset{b: b, init: init, ctx: ctx}
+
+	_, err := asset.NewGraph().Run(destroy)
+
+	return err
+}
+
+// Plan runs terraform plan against the provisioner's working directory,
+// writing a machine-readable plan plus a human-readable summary. It returns
+// true when drift was detected, letting callers (e.g. CI) exit non-zero.
+// The run is aborted when ctx is cancelled.
+func (b *Bootstrap) Plan(ctx context.Context) (bool, error) {
+	config := &configAsset{b: b}
+	init := &terraformInitAsset{b: b, config: config, ctx: ctx}
+	plan := &terraformPlanAsset{b: b, init: init, ctx: ctx}
+
+	res, err := asset.NewGraph().Run(plan)
+	if err != nil {
+		return false, err
+	}
+
+	driftDetected, _ := res.(bool)
+
+	return driftDetected, nil
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2020 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rules declares, per bootstrap provisioner, which configuration
+// paths must not change once the infrastructure has been applied.
+package rules
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"gopkg.in/yaml.v2"
+)
+
+//go:embed data
+var dataFS embed.FS
+
+// Rules lists the immutable paths of a single bootstrap provisioner.
+type Rules struct {
+	Immutable []string `yaml:"immutable"`
+}
+
+// Builder gives access to the immutable paths declared for a bootstrap
+// provisioner.
+type Builder struct {
+	rules Rules
+}
+
+// NewBootstrapRulesBuilder loads the immutability rules for the given
+// bootstrap provisioner (e.g. "aws"). A provisioner with no rules file
+// declares no immutable paths, instead of failing.
+func NewBootstrapRulesBuilder(provisioner string) (*Builder, error) {
+	content, err := dataFS.ReadFile(path.Join("data", provisioner+".yml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Builder{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading rules for provisioner %v: %w", provisioner, err)
+	}
+
+	var rules Rules
+
+	if err := yaml.Unmarshal(content, &rules); err != nil {
+		return nil, fmt.Errorf("error parsing rules for provisioner %v: %w", provisioner, err)
+	}
+
+	return &Builder{rules: rules}, nil
+}
+
+// GetImmutables returns the configuration paths that must not change once
+// the provisioner has been applied.
+func (b *Builder) GetImmutables() []string {
+	return b.rules.Immutable
+}
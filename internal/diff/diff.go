@@ -0,0 +1,142 @@
+// Copyright (c) 2020 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package diff computes path-level differences between two configuration
+// trees, used to detect changes to fields that must not be mutated once a
+// provisioner has been applied.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Diff represents a single changed path between two configuration trees.
+type Diff struct {
+	Path string `json:"path"`
+	From any    `json:"from"`
+	To   any    `json:"to"`
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("%s: %v -> %v", d.Path, d.From, d.To)
+}
+
+// BaseChecker diffs two arbitrary configuration trees, as produced by
+// unmarshalling YAML into map[string]any.
+type BaseChecker struct {
+	before map[string]any
+	after  map[string]any
+}
+
+// NewBaseChecker creates a BaseChecker that diffs before against after.
+func NewBaseChecker(before, after map[string]any) *BaseChecker {
+	return &BaseChecker{before: before, after: after}
+}
+
+// GenerateDiff walks both trees and returns every path whose value changed,
+// was added, or was removed, sorted by path for stable output.
+func (c *BaseChecker) GenerateDiff() ([]Diff, error) {
+	diffs := map[string]Diff{}
+
+	walk("", c.before, c.after, diffs)
+
+	result := make([]Diff, 0, len(diffs))
+	for _, d := range diffs {
+		result = append(result, d)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+
+	return result, nil
+}
+
+// walk compares before against after over the union of their keys, writing
+// one Diff per path that was added (From nil), removed (To nil), or whose
+// value changed (From before's value, To after's value).
+func walk(prefix string, before, after map[string]any, diffs map[string]Diff) {
+	keys := make(map[string]bool, len(before)+len(after))
+	for k := range before {
+		keys[k] = true
+	}
+
+	for k := range after {
+		keys[k] = true
+	}
+
+	for k := range keys {
+		p := k
+		if prefix != "" {
+			p = prefix + "." + k
+		}
+
+		bv, bok := before[k]
+		av, aok := after[k]
+
+		switch {
+		case !bok:
+			diffs[p] = Diff{Path: p, From: nil, To: av}
+		case !aok:
+			diffs[p] = Diff{Path: p, From: bv, To: nil}
+		default:
+			bm, bmok := bv.(map[string]any)
+			am, amok := av.(map[string]any)
+
+			switch {
+			case bmok && amok:
+				walk(p, bm, am, diffs)
+			case fmt.Sprintf("%v", bv) != fmt.Sprintf("%v", av):
+				diffs[p] = Diff{Path: p, From: bv, To: av}
+			}
+		}
+	}
+}
+
+// TopLevelPaths collapses each diff path down to its top two segments (e.g.
+// "spec.networkCIDR.value" becomes "spec.networkCIDR"), deduplicates and
+// sorts the result. It is used to report which spec fields changed without
+// drowning the caller in every nested field.
+func TopLevelPaths(diffs []Diff) []string {
+	seen := map[string]bool{}
+
+	for _, d := range diffs {
+		parts := strings.SplitN(d.Path, ".", 3)
+		if len(parts) > 2 {
+			parts = parts[:2]
+		}
+
+		seen[strings.Join(parts, ".")] = true
+	}
+
+	paths := make([]string, 0, len(seen))
+	for p := range seen {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// AssertImmutableViolations returns one error per diff whose path matches
+// one of the given immutable paths.
+func AssertImmutableViolations(diffs []Diff, immutablePaths []string) []error {
+	var errs []error
+
+	immutable := make(map[string]bool, len(immutablePaths))
+	for _, p := range immutablePaths {
+		immutable[p] = true
+	}
+
+	for _, d := range diffs {
+		if immutable[d.Path] {
+			errs = append(errs, fmt.Errorf("%s", d.String())) //nolint:goerr113 // aggregated by the caller
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2020 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package diff
+
+import (
+	"testing"
+)
+
+func TestGenerateDiff(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		before map[string]any
+		after  map[string]any
+		want   []Diff
+	}{
+		{
+			desc:   "added field",
+			before: map[string]any{"spec": map[string]any{"region": "x"}},
+			after:  map[string]any{"spec": map[string]any{"region": "x", "newField": "added-value"}},
+			want:   []Diff{{Path: "spec.newField", From: nil, To: "added-value"}},
+		},
+		{
+			desc:   "removed field",
+			before: map[string]any{"spec": map[string]any{"region": "x", "oldField": "removed-value"}},
+			after:  map[string]any{"spec": map[string]any{"region": "x"}},
+			want:   []Diff{{Path: "spec.oldField", From: "removed-value", To: nil}},
+		},
+		{
+			desc:   "changed field",
+			before: map[string]any{"spec": map[string]any{"region": "a"}},
+			after:  map[string]any{"spec": map[string]any{"region": "b"}},
+			want:   []Diff{{Path: "spec.region", From: "a", To: "b"}},
+		},
+		{
+			desc:   "unchanged field",
+			before: map[string]any{"spec": map[string]any{"region": "a"}},
+			after:  map[string]any{"spec": map[string]any{"region": "a"}},
+			want:   nil,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			diffs, err := NewBaseChecker(tC.before, tC.after).GenerateDiff()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(diffs) != len(tC.want) {
+				t.Fatalf("got %d diffs, want %d: %v", len(diffs), len(tC.want), diffs)
+			}
+
+			for i, d := range diffs {
+				if d != tC.want[i] {
+					t.Errorf("diff %d = %+v, want %+v", i, d, tC.want[i])
+				}
+			}
+		})
+	}
+}
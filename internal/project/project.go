@@ -0,0 +1,40 @@
+// Copyright (c) 2020 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package project manages the on-disk working directory created by the
+// bootstrap and cluster provisioners.
+package project
+
+import (
+	"fmt"
+	"os"
+)
+
+// Project represents the on-disk working directory of a provisioner run.
+type Project struct {
+	Path string
+}
+
+// Check returns an error when the project directory does not exist yet.
+func (p *Project) Check() error {
+	if _, err := os.Stat(p.Path); err != nil {
+		return fmt.Errorf("error checking project directory %v: %w", p.Path, err)
+	}
+
+	return nil
+}
+
+// Create creates the project working directory. It fails if the directory
+// already exists.
+func (p *Project) Create() error {
+	if err := p.Check(); err == nil {
+		return fmt.Errorf("the project %v already exists", p.Path)
+	}
+
+	if err := os.MkdirAll(p.Path, 0o755); err != nil {
+		return fmt.Errorf("error creating project directory %v: %w", p.Path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2020 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrNoAppliedConfig is returned by LocalStorer.GetConfig when no
+// configuration has been stored yet, i.e. the provisioner has never been
+// applied successfully.
+var ErrNoAppliedConfig = errors.New("no previously applied configuration found")
+
+// LocalStorer persists the last successfully applied configuration as a
+// plain file inside the provisioner's working directory. It is local-only:
+// unlike the terraform state itself, this file is not read from the
+// configured terraform backend (s3/gcs/azurerm/remote), so teams sharing a
+// remote backend must share this file themselves (e.g. commit it) to get
+// working `bootstrap diff`/immutable-field checks across machines and CI.
+type LocalStorer struct {
+	// Path is the file where the last applied configuration is stored.
+	Path string
+}
+
+// NewLocalStorer creates a LocalStorer backed by the file at path.
+func NewLocalStorer(path string) *LocalStorer {
+	return &LocalStorer{Path: path}
+}
+
+// GetConfig reads the last successfully applied configuration from disk.
+func (s *LocalStorer) GetConfig() (string, error) {
+	content, err := os.ReadFile(s.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrNoAppliedConfig
+		}
+
+		return "", fmt.Errorf("error reading stored configuration %v: %w", s.Path, err)
+	}
+
+	return string(content), nil
+}
+
+// StoreConfig writes content as the last successfully applied configuration.
+func (s *LocalStorer) StoreConfig(content string) error {
+	if err := os.WriteFile(s.Path, []byte(content), 0o644); err != nil { //nolint:gosec // applied config is not sensitive
+		return fmt.Errorf("error storing configuration %v: %w", s.Path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2020 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package state retrieves the configuration that was in effect the last
+// time a provisioner was successfully applied, so it can be diffed against
+// the configuration about to be applied.
+package state
+
+// Storer retrieves and persists the configuration used in the last
+// successful apply of a provisioner run.
+type Storer interface {
+	// GetConfig returns the raw YAML of the last successfully applied
+	// configuration.
+	GetConfig() (string, error)
+	// StoreConfig persists the raw YAML of a successfully applied
+	// configuration.
+	StoreConfig(content string) error
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2020 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package backend renders the terraform backend.tf declaration for a
+// provisioner's working directory, so state can be kept remotely instead of
+// on the local filesystem. It is shared by the bootstrap and cluster
+// provisioners.
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// Config describes the terraform backend declared in a bootstrap or cluster
+// configuration file, mirroring configuration.StateConfiguration.
+type Config struct {
+	// Type is the terraform backend name: s3, gcs, azurerm or remote. An
+	// empty Type (or "local") keeps terraform's default local state and no
+	// backend.tf is rendered.
+	Type string
+	// Values holds the backend-specific settings, e.g. bucketName/region
+	// for s3, verbatim from the configuration file.
+	Values map[string]string
+}
+
+var templates = map[string]string{
+	"s3": `terraform {
+  backend "s3" {
+    bucket                 = "{{.bucketName}}"
+    key                    = "{{.keyPrefix}}/terraform.tfstate"
+    region                 = "{{.region}}"
+    skip_region_validation = {{.skipRegionValidation}}
+  }
+}
+`,
+	"gcs": `terraform {
+  backend "gcs" {
+    bucket = "{{.bucket}}"
+    prefix = "{{.prefix}}"
+  }
+}
+`,
+	"azurerm": `terraform {
+  backend "azurerm" {
+    storage_account_name = "{{.storageAccountName}}"
+    container_name       = "{{.containerName}}"
+    key                   = "{{.key}}"
+    resource_group_name  = "{{.resourceGroupName}}"
+  }
+}
+`,
+	"remote": `terraform {
+  backend "remote" {
+    organization = "{{.organization}}"
+
+    workspaces {
+      name = "{{.workspace}}"
+    }
+  }
+}
+`,
+}
+
+// defaults holds fallback values for optional backend settings, applied
+// whenever the configuration leaves them unset (or blank), so the rendered
+// HCL is always valid even when the corresponding config field is omitted.
+var defaults = map[string]map[string]string{
+	"s3": {"skipRegionValidation": "false"},
+}
+
+// withDefaults overlays the given values on top of backendType's defaults,
+// so explicit values win and unset ones fall back.
+func withDefaults(backendType string, values map[string]string) map[string]string {
+	merged := make(map[string]string, len(values)+len(defaults[backendType]))
+
+	for k, v := range values {
+		merged[k] = v
+	}
+
+	for k, d := range defaults[backendType] {
+		if merged[k] == "" {
+			merged[k] = d
+		}
+	}
+
+	return merged
+}
+
+// Render writes a backend.tf file under dir declaring the given backend.
+// When cfg.Type is empty or "local", no file is written and terraform falls
+// back to its default local state.
+func Render(dir string, cfg Config) error {
+	if cfg.Type == "" || cfg.Type == "local" {
+		return nil
+	}
+
+	tpl, ok := templates[cfg.Type]
+	if !ok {
+		return fmt.Errorf("unsupported terraform backend %q", cfg.Type)
+	}
+
+	t, err := template.New("backend").Parse(tpl)
+	if err != nil {
+		return fmt.Errorf("error parsing backend template for %q: %w", cfg.Type, err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, withDefaults(cfg.Type, cfg.Values)); err != nil {
+		return fmt.Errorf("error rendering backend template for %q: %w", cfg.Type, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "backend.tf"), buf.Bytes(), 0o644); err != nil { //nolint:gosec // backend.tf is not sensitive
+		return fmt.Errorf("error writing backend.tf: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2020 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderS3DefaultsSkipRegionValidation(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		values map[string]string
+		want   string
+	}{
+		{
+			desc: "skipRegionValidation omitted",
+			values: map[string]string{
+				"bucketName": "my-bucket",
+				"keyPrefix":  "bootstrap",
+				"region":     "eu-west-1",
+			},
+			want: "skip_region_validation = false",
+		},
+		{
+			desc: "skipRegionValidation set explicitly",
+			values: map[string]string{
+				"bucketName":           "my-bucket",
+				"keyPrefix":            "bootstrap",
+				"region":               "eu-west-1",
+				"skipRegionValidation": "true",
+			},
+			want: "skip_region_validation = true",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			dir := t.TempDir()
+
+			if err := Render(dir, Config{Type: "s3", Values: tC.values}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, "backend.tf"))
+			if err != nil {
+				t.Fatalf("error reading backend.tf: %v", err)
+			}
+
+			if !strings.Contains(string(content), tC.want) {
+				t.Errorf("backend.tf = %q, want it to contain %q", content, tC.want)
+			}
+
+			if strings.Contains(string(content), "<no value>") {
+				t.Errorf("backend.tf contains an unresolved template value: %q", content)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2020 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package analytics reports anonymous usage events for furyctl commands.
+package analytics
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+func track(event string, success bool, provisioner string) {
+	log.Debugf("analytics: %v success=%v provisioner=%v", event, success, provisioner)
+}
+
+// TrackBootstrapInit reports a `bootstrap init` run.
+func TrackBootstrapInit(token string, success bool, provisioner string) {
+	track("bootstrap-init", success, provisioner)
+}
+
+// TrackBootstrapUpdate reports a `bootstrap update` run.
+func TrackBootstrapUpdate(token string, success bool, provisioner string, dryRun bool) {
+	track("bootstrap-update", success, provisioner)
+}
+
+// TrackBootstrapDestroy reports a `bootstrap destroy` run.
+func TrackBootstrapDestroy(token string, success bool, provisioner string) {
+	track("bootstrap-destroy", success, provisioner)
+}
+
+// TrackBootstrapPlan reports a `bootstrap plan` run.
+func TrackBootstrapPlan(token string, success bool, provisioner string, driftDetected bool) {
+	track("bootstrap-plan", success, provisioner)
+}
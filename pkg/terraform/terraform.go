@@ -0,0 +1,282 @@
+// Copyright (c) 2020 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package terraform wraps the execution of the terraform binary against a
+// provisioner's working directory.
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/terraform/backend"
+)
+
+// Options configures how the terraform runner executes against a working
+// directory.
+type Options struct {
+	// GitHubToken is used to authenticate against enterprise terraform modules.
+	GitHubToken string
+	// WorkingDir is the directory where the terraform files live.
+	WorkingDir string
+	// Debug enables verbose terraform output.
+	Debug bool
+	// PlanOut is the directory where Plan() writes the binary plan file
+	// (plan.tfplan) and its JSON representation (plan.json). The human
+	// readable summary always goes to WorkingDir, whether PlanOut is set or not.
+	PlanOut string
+	// Backend declares the terraform state backend to render into the
+	// working directory before `terraform init`. Left zero-valued, it keeps
+	// terraform's default local state.
+	Backend backend.Config
+}
+
+// checkpointFileName is written to the working directory whenever a
+// terraform run is cancelled mid-flight, as a diagnostic record of what
+// stage was interrupted.
+const checkpointFileName = ".furyctl-checkpoint.json"
+
+// Checkpoint records the stage that was interrupted by a cancelled context.
+// It is informational only: furyctl does not read it back to resume a run,
+// but it lets an operator tell which stage got cut off.
+type Checkpoint struct {
+	Stage       string    `json:"stage"`
+	CancelledAt time.Time `json:"cancelledAt"`
+}
+
+// Runner executes terraform commands against the configured working
+// directory.
+type Runner struct {
+	opts *Options
+}
+
+// NewRunner creates a terraform Runner for the given Options.
+func NewRunner(opts *Options) *Runner {
+	return &Runner{opts: opts}
+}
+
+func (r *Runner) env() []string {
+	env := os.Environ()
+	if r.opts.GitHubToken != "" {
+		env = append(env, fmt.Sprintf("GITHUB_TOKEN=%v", r.opts.GitHubToken))
+	}
+	return env
+}
+
+// lockInfoPattern extracts the lock ID out of the "Lock Info:" block that
+// terraform prints when a command can't acquire the state lock. It requires
+// the "Lock Info:" header so it doesn't match unrelated "ID:" lines that may
+// appear in normal resource/output values.
+var lockInfoPattern = regexp.MustCompile(`(?s)Lock Info:.*?ID:\s+([0-9a-fA-F-]+)`)
+
+// run executes terraform with args under ctx. If ctx is cancelled while the
+// process is running, it writes a diagnostic checkpoint for stage and probes
+// for a state lock left behind by the cancelled command, releasing it with
+// `terraform force-unlock` when found.
+func (r *Runner) run(ctx context.Context, stage string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "terraform", args...)
+	cmd.Dir = r.opts.WorkingDir
+	cmd.Env = r.env()
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = 10 * time.Second
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if r.opts.Debug {
+		log.Debugf("running terraform %v in %v", args, r.opts.WorkingDir)
+	}
+
+	runErr := cmd.Run()
+
+	if ctx.Err() != nil {
+		if err := r.writeCheckpoint(stage); err != nil {
+			log.Errorf("error writing checkpoint: %v", err)
+		}
+
+		if lockID := r.probeLockID(); lockID != "" {
+			if err := r.forceUnlock(lockID); err != nil {
+				log.Errorf("error releasing terraform state lock %v: %v", lockID, err)
+			}
+		}
+
+		return out.String(), fmt.Errorf("terraform %v cancelled: %w", args, ctx.Err())
+	}
+
+	if runErr != nil {
+		return out.String(), fmt.Errorf("error running terraform %v: %w: %s", args, runErr, out.String())
+	}
+
+	return out.String(), nil
+}
+
+// probeLockTimeout bounds how long probeLockID may run, so a cancelled
+// (e.g. Ctrl-C'd) bootstrap command never blocks again on a second, hung
+// terraform process.
+const probeLockTimeout = 5 * time.Second
+
+// probeLockID runs a harmless, short-lock-timeout terraform command to check
+// whether the working directory's state is still locked after a cancelled
+// run, returning the lock ID terraform reports, or "" when it isn't locked
+// (e.g. the cancelled process already released it on exit) or the probe
+// itself didn't finish within probeLockTimeout.
+func (r *Runner) probeLockID() string {
+	ctx, cancel := context.WithTimeout(context.Background(), probeLockTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "terraform", "plan", "-lock-timeout=2s", "-input=false", "-detailed-exitcode")
+	cmd.Dir = r.opts.WorkingDir
+	cmd.Env = r.env()
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = 2 * time.Second
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	_ = cmd.Run()
+
+	if m := lockInfoPattern.FindStringSubmatch(out.String()); m != nil {
+		return m[1]
+	}
+
+	return ""
+}
+
+// forceUnlock releases the state lock with the given ID using a fresh,
+// non-cancellable context, so it runs even after the caller's context was
+// cancelled.
+func (r *Runner) forceUnlock(lockID string) error {
+	cmd := exec.Command("terraform", "force-unlock", "-force", lockID)
+	cmd.Dir = r.opts.WorkingDir
+	cmd.Env = r.env()
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error force-unlocking state %v: %w: %s", lockID, err, out.String())
+	}
+
+	return nil
+}
+
+func (r *Runner) writeCheckpoint(stage string) error {
+	content, err := json.Marshal(Checkpoint{Stage: stage, CancelledAt: time.Now()})
+	if err != nil {
+		return fmt.Errorf("error marshalling checkpoint: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(r.opts.WorkingDir, checkpointFileName), content, 0o644); err != nil { //nolint:gosec // checkpoint is not sensitive
+		return fmt.Errorf("error writing checkpoint file: %w", err)
+	}
+
+	return nil
+}
+
+// Init renders the configured state backend, if any, and runs
+// `terraform init` against the working directory.
+func (r *Runner) Init(ctx context.Context) error {
+	if err := backend.Render(r.opts.WorkingDir, r.opts.Backend); err != nil {
+		return fmt.Errorf("error rendering terraform backend: %w", err)
+	}
+
+	_, err := r.run(ctx, "init", "init")
+	return err
+}
+
+// Apply runs `terraform apply`, performing a dry run (plan only) when
+// dryRun is true.
+func (r *Runner) Apply(ctx context.Context, dryRun bool) error {
+	if dryRun {
+		_, err := r.run(ctx, "apply", "plan")
+		return err
+	}
+
+	_, err := r.run(ctx, "apply", "apply", "-auto-approve")
+	return err
+}
+
+// Destroy runs `terraform destroy` against the working directory.
+func (r *Runner) Destroy(ctx context.Context) error {
+	_, err := r.run(ctx, "destroy", "destroy", "-auto-approve")
+	return err
+}
+
+// PlanResult describes the outcome of a Plan() execution.
+type PlanResult struct {
+	// DriftDetected is true when terraform reports changes to apply.
+	DriftDetected bool
+	// PlanFile is the path to the binary plan file, when PlanOut was set.
+	PlanFile string
+	// JSONFile is the path to the JSON representation of the plan.
+	JSONFile string
+}
+
+// Plan runs `terraform plan`, writing a binary plan file plus a JSON
+// representation to Options.PlanOut when set, and a human-readable summary
+// (plan-summary.txt) to Options.WorkingDir.
+// It reports drift via PlanResult.DriftDetected, using terraform's
+// `-detailed-exitcode` convention (exit code 2 means changes are present).
+// Like Init/Apply/Destroy, it goes through run so a cancelled context still
+// leaves a checkpoint and releases any state lock left behind.
+func (r *Runner) Plan(ctx context.Context) (*PlanResult, error) {
+	args := []string{"plan", "-detailed-exitcode"}
+
+	result := &PlanResult{}
+
+	if r.opts.PlanOut != "" {
+		if err := os.MkdirAll(r.opts.PlanOut, 0o755); err != nil {
+			return nil, fmt.Errorf("error creating plan output directory: %w", err)
+		}
+
+		result.PlanFile = filepath.Join(r.opts.PlanOut, "plan.tfplan")
+		result.JSONFile = filepath.Join(r.opts.PlanOut, "plan.json")
+
+		args = append(args, "-out", result.PlanFile)
+	}
+
+	out, err := r.run(ctx, "plan", args...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 2 {
+			return nil, err
+		}
+
+		result.DriftDetected = true
+	}
+
+	if err := os.WriteFile(filepath.Join(r.opts.WorkingDir, "plan-summary.txt"), []byte(out), 0o644); err != nil { //nolint:gosec // summary is not sensitive
+		return nil, fmt.Errorf("error writing plan summary: %w", err)
+	}
+
+	if result.PlanFile != "" {
+		show, err := r.run(ctx, "plan", "show", "-json", result.PlanFile)
+		if err != nil {
+			return nil, fmt.Errorf("error converting plan to json: %w", err)
+		}
+
+		if err := os.WriteFile(result.JSONFile, []byte(show), 0o644); err != nil { //nolint:gosec // plan json is not sensitive
+			return nil, fmt.Errorf("error writing plan json: %w", err)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2020 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terraform
+
+import "testing"
+
+func TestLockInfoPattern(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		output string
+		want   string
+	}{
+		{
+			desc: "lock info block",
+			output: `Acquiring state lock. This may take a few moments...
+╷
+│ Error: Error acquiring the state lock
+│
+│ Lock Info:
+│   ID:        1a2b3c4d-5e6f-7a8b-9c0d-1e2f3a4b5c6d
+│   Path:      terraform.tfstate
+│   Operation: OperationTypeApply
+╵
+`,
+			want: "1a2b3c4d-5e6f-7a8b-9c0d-1e2f3a4b5c6d",
+		},
+		{
+			desc:   "no lock held",
+			output: "Apply complete! Resources: 1 added, 0 changed, 0 destroyed.\n",
+			want:   "",
+		},
+		{
+			desc:   "unrelated ID-shaped output value, no Lock Info header",
+			output: "Outputs:\n\n  ID:  1a2b3c4d-5e6f-7a8b-9c0d-1e2f3a4b5c6d\n",
+			want:   "",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			m := lockInfoPattern.FindStringSubmatch(tC.output)
+
+			got := ""
+			if m != nil {
+				got = m[1]
+			}
+
+			if got != tC.want {
+				t.Errorf("lockInfoPattern match = %q, want %q", got, tC.want)
+			}
+		})
+	}
+}